@@ -0,0 +1,60 @@
+// SPDX-License-Identifier: MIT OR Unlicense
+
+package processor
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Explanation is a node in the tree of reasons a result scored the way
+// it did, modelled on bleve's Explanation. One child is added per
+// contributing ranker (TF-IDF, BM25, location, the v2 path bonus, ...),
+// each describing its own contribution to Value.
+//
+// An Explanation is only built for a fileJob when its Explain field is
+// set, since walking MatchLocations a second time to describe the score
+// has a real, if small, cost per result.
+type Explanation struct {
+	Value    float64        `json:"value"`
+	Message  string         `json:"message"`
+	Children []*Explanation `json:"children,omitempty"`
+}
+
+// addExplanation appends child as a new top level contribution to res's
+// Explanation tree, creating the root "sum of:" node on first use. It is
+// a no-op when res.Explain is false, so rankers can call it
+// unconditionally without checking the flag themselves.
+func addExplanation(res *fileJob, child *Explanation) {
+	if !res.Explain || child == nil {
+		return
+	}
+
+	if res.Explanation == nil {
+		res.Explanation = &Explanation{Message: "sum of:"}
+	}
+
+	res.Explanation.Value += child.Value
+	res.Explanation.Children = append(res.Explanation.Children, child)
+}
+
+// String renders the Explanation as an indented tree suitable for
+// printing underneath a result in the default formatter.
+func (e *Explanation) String() string {
+	var b strings.Builder
+	e.write(&b, 0)
+	return b.String()
+}
+
+func (e *Explanation) write(b *strings.Builder, depth int) {
+	if e == nil {
+		return
+	}
+
+	b.WriteString(strings.Repeat("  ", depth))
+	b.WriteString(fmt.Sprintf("%.4f = %s\n", e.Value, e.Message))
+
+	for _, child := range e.Children {
+		child.write(b, depth+1)
+	}
+}
@@ -0,0 +1,61 @@
+// SPDX-License-Identifier: MIT
+// SPDX-License-Identifier: Unlicense
+
+package processor
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestRankResultsExplainPopulatesExplanation(t *testing.T) {
+	ml := map[string][][]int{}
+	ml["example"] = [][]int{{1}, {2}}
+
+	s := []*fileJob{
+		{MatchLocations: ml, Location: "/test/example.go", Bytes: 12, Explain: true},
+	}
+
+	s = rankResultsTFIDF(1, s)
+	s = rankResultsLocation(s)
+
+	if s[0].Explanation == nil {
+		t.Fatal("expected an Explanation to be populated when Explain is true")
+	}
+
+	if len(s[0].Explanation.Children) == 0 {
+		t.Error("expected at least one contributing ranker in the Explanation tree")
+	}
+}
+
+func TestRankResultsWithoutExplainLeavesExplanationNil(t *testing.T) {
+	ml := map[string][][]int{}
+	ml["example"] = [][]int{{1}}
+
+	s := []*fileJob{
+		{MatchLocations: ml, Location: "/test/example.go", Bytes: 12},
+	}
+
+	s = rankResultsTFIDF(1, s)
+
+	if s[0].Explanation != nil {
+		t.Error("expected no Explanation to be built when Explain is false")
+	}
+}
+
+func TestExplanationStringRendersTree(t *testing.T) {
+	e := &Explanation{
+		Value:   1.5,
+		Message: "sum of:",
+		Children: []*Explanation{
+			{Value: 1.0, Message: "tf-idf: 1.0000"},
+			{Value: 0.5, Message: "location boost: +0.50"},
+		},
+	}
+
+	out := e.String()
+
+	if !strings.Contains(out, "sum of:") || !strings.Contains(out, "tf-idf") || !strings.Contains(out, "location boost") {
+		t.Errorf("expected rendered tree to mention every node, got %q", out)
+	}
+}
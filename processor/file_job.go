@@ -0,0 +1,33 @@
+// SPDX-License-Identifier: MIT OR Unlicense
+
+package processor
+
+// fileJob carries a single file through the pipeline from disk, to the
+// searcher, to ranking and finally to snippet extraction and display.
+// Later stages populate more of the struct than earlier ones, so callers
+// should not assume every field is set until the pipeline has finished
+// with a given instance.
+type fileJob struct {
+	Filename string
+	Location string
+	Content  []byte
+	Bytes    int
+
+	// MatchLocations holds, per matched term, the byte offset ranges
+	// into Content where that term was found. It is the common
+	// currency between the searcher, the rankers and the snippet
+	// extractors.
+	MatchLocations map[string][][]int
+
+	Score float64
+
+	// Explain, when set, causes every ranker in result_ranker.go and
+	// result_ranker_v2.go to record its contribution to Score onto
+	// Explanation as well as folding it into the total.
+	Explain     bool
+	Explanation *Explanation
+
+	// SearchSet is the trigram index built for this file when --index
+	// is in effect. It is nil unless ingestion built or loaded one.
+	SearchSet *SearchSet
+}
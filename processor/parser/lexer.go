@@ -0,0 +1,135 @@
+// SPDX-License-Identifier: MIT OR Unlicense
+
+package parser
+
+import (
+	"strings"
+	"unicode"
+)
+
+// Token types produced by the Lexer. These map directly onto the
+// terminals understood by the Parser grammar.
+const (
+	TokenAnd    = "AND"
+	TokenOr     = "OR"
+	TokenNot    = "NOT"
+	TokenLParen = "LPAREN"
+	TokenRParen = "RPAREN"
+	TokenTerm   = "TERM"
+	TokenPhrase = "PHRASE"
+	TokenFuzzy  = "FUZZY"
+	TokenRegex  = "REGEX"
+	TokenField  = "FIELD"
+	TokenEOF    = "EOF"
+)
+
+// knownFields lists the field names classifyWord recognizes before a
+// colon, kept in sync with the field clauses processor.evalField
+// understands (ext, path and size). Requiring the prefix to be one of
+// these, rather than just non-empty, is what keeps a Windows style
+// drive letter such as "C:\Users\foo.go" from being misread as a field.
+var knownFields = map[string]bool{
+	"ext":  true,
+	"path": true,
+	"size": true,
+}
+
+// Token is a single lexical unit produced from a raw query string.
+// Field is only populated on TokenField tokens and holds the part
+// before the colon, e.g. "ext" in "ext:go".
+type Token struct {
+	Type  string
+	Value string
+	Field string
+}
+
+// Lexer turns a raw query string into the flat slice of Tokens the
+// Parser consumes. It understands "double quoted phrases", /slash
+// delimited regular expressions/, a trailing ~ fuzzy marker, field:value
+// pairs and the AND/OR/NOT keywords plus parentheses for grouping.
+type Lexer struct {
+	input string
+}
+
+// NewLexer creates a Lexer ready to tokenize input.
+func NewLexer(input string) Lexer {
+	return Lexer{
+		input: input,
+	}
+}
+
+// Tokens scans the entire input and returns the resulting slice of Token.
+// Unterminated phrases/regexes run to the end of the input rather than
+// erroring, leaving it up to the Parser to reject anything that does not
+// make sense.
+func (l Lexer) Tokens() []Token {
+	var tokens []Token
+
+	runes := []rune(l.input)
+	n := len(runes)
+	i := 0
+
+	for i < n {
+		c := runes[i]
+
+		switch {
+		case unicode.IsSpace(c):
+			i++
+		case c == '(':
+			tokens = append(tokens, Token{Type: TokenLParen, Value: "("})
+			i++
+		case c == ')':
+			tokens = append(tokens, Token{Type: TokenRParen, Value: ")"})
+			i++
+		case c == '"':
+			j := i + 1
+			for j < n && runes[j] != '"' {
+				j++
+			}
+			tokens = append(tokens, Token{Type: TokenPhrase, Value: string(runes[i+1 : j])})
+			i = j + 1
+		case c == '/':
+			j := i + 1
+			for j < n && runes[j] != '/' {
+				j++
+			}
+			tokens = append(tokens, Token{Type: TokenRegex, Value: string(runes[i+1 : j])})
+			i = j + 1
+		default:
+			j := i
+			for j < n && !unicode.IsSpace(runes[j]) && runes[j] != '(' && runes[j] != ')' {
+				j++
+			}
+			word := string(runes[i:j])
+			i = j
+
+			tokens = append(tokens, classifyWord(word))
+		}
+	}
+
+	return tokens
+}
+
+// classifyWord turns a single whitespace delimited word into the most
+// specific Token it can be: a keyword, a fuzzy term, a field:value pair
+// or, failing all of those, a plain TokenTerm.
+func classifyWord(word string) Token {
+	switch word {
+	case TokenAnd, TokenOr, TokenNot:
+		return Token{Type: word, Value: word}
+	}
+
+	if strings.HasSuffix(word, "~") && len(word) > 1 {
+		return Token{Type: TokenFuzzy, Value: strings.TrimSuffix(word, "~")}
+	}
+
+	// Field scoped terms look like `ext:go` or `size:>1000`. The prefix
+	// must be one of knownFields so that a bare ":" or a Windows style
+	// drive letter such as "C:\Users\foo.go" does not get misread as a
+	// field.
+	if idx := strings.Index(word, ":"); idx > 0 && knownFields[word[:idx]] {
+		return Token{Type: TokenField, Field: word[:idx], Value: word[idx+1:]}
+	}
+
+	return Token{Type: TokenTerm, Value: word}
+}
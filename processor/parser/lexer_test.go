@@ -0,0 +1,26 @@
+// SPDX-License-Identifier: MIT
+// SPDX-License-Identifier: Unlicense
+
+package parser
+
+import "testing"
+
+func TestClassifyWordRecognizesKnownFields(t *testing.T) {
+	tok := classifyWord("ext:go")
+
+	if tok.Type != TokenField || tok.Field != "ext" || tok.Value != "go" {
+		t.Errorf("expected ext field token, got %+v", tok)
+	}
+}
+
+func TestClassifyWordRejectsWindowsDriveLetter(t *testing.T) {
+	tok := classifyWord(`C:\Users\foo.go`)
+
+	if tok.Type == TokenField {
+		t.Errorf("expected a drive letter not to be misread as a field, got %+v", tok)
+	}
+
+	if tok.Type != TokenTerm || tok.Value != `C:\Users\foo.go` {
+		t.Errorf("expected the drive letter word to pass through as a plain term, got %+v", tok)
+	}
+}
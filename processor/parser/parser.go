@@ -1,36 +1,194 @@
+// SPDX-License-Identifier: MIT OR Unlicense
+
 package parser
 
 import "fmt"
 
+// Expr Op values. Leaf nodes (TERM, PHRASE, FUZZY, REGEX, FIELD) carry
+// their value in Val (and Field for FIELD); AND/OR carry both Left and
+// Right; NOT only carries Left.
+const (
+	OpAnd    = "AND"
+	OpOr     = "OR"
+	OpNot    = "NOT"
+	OpTerm   = "TERM"
+	OpPhrase = "PHRASE"
+	OpFuzzy  = "FUZZY"
+	OpRegex  = "REGEX"
+	OpField  = "FIELD"
+)
+
+// Expr is a node in the query AST produced by Parser.Parse. It is
+// intentionally untyped data only (no evaluation logic lives here) so
+// that the parser package has no dependency on how a caller chooses to
+// evaluate it against a document.
 type Expr struct {
 	Op    string
 	Left  *Expr
 	Right *Expr
 	Val   string
+	Field string
 }
 
+// Parser is a recursive descent parser over the Tokens produced by a
+// Lexer. The grammar, in descending precedence, is
+//
+//	query   := orExpr
+//	orExpr  := andExpr (OR andExpr)*
+//	andExpr := notExpr ((AND)? notExpr)*
+//	notExpr := NOT notExpr | primary
+//	primary := "(" orExpr ")" | TERM | PHRASE | FUZZY | REGEX | FIELD
+//
+// Two clauses placed next to each other with no explicit operator are
+// treated as an implicit AND, e.g. `ext:go error` is `ext:go AND error`.
 type Parser struct {
-	lexer Lexer
+	tokens []Token
+	pos    int
 }
 
+// NewParser creates a Parser over the Tokens produced by lexer.
 func NewParser(lexer Lexer) Parser {
 	return Parser{
-		lexer: lexer,
+		tokens: lexer.Tokens(),
+	}
+}
+
+// Parse consumes the Parser's Tokens and returns the resulting Expr tree.
+// It returns an error if the query is empty, malformed (e.g. unbalanced
+// parentheses) or if trailing tokens remain once a complete expression
+// has been parsed.
+func (p *Parser) Parse() (*Expr, error) {
+	if len(p.tokens) == 0 {
+		return nil, fmt.Errorf("parser: empty query")
+	}
+
+	expr, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+
+	if p.pos != len(p.tokens) {
+		return nil, fmt.Errorf("parser: unexpected token %q", p.peek().Value)
+	}
+
+	return expr, nil
+}
+
+func (p *Parser) parseOr() (*Expr, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+
+	for p.peek().Type == TokenOr {
+		p.advance()
+
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+
+		left = &Expr{Op: OpOr, Left: left, Right: right}
+	}
+
+	return left, nil
+}
+
+func (p *Parser) parseAnd() (*Expr, error) {
+	left, err := p.parseNot()
+	if err != nil {
+		return nil, err
 	}
+
+	for {
+		switch p.peek().Type {
+		case TokenAnd:
+			p.advance()
+
+			right, err := p.parseNot()
+			if err != nil {
+				return nil, err
+			}
+
+			left = &Expr{Op: OpAnd, Left: left, Right: right}
+		case TokenOr, TokenRParen, TokenEOF:
+			return left, nil
+		default:
+			// Two adjacent clauses with no explicit operator between them
+			// are implicitly ANDed together.
+			right, err := p.parseNot()
+			if err != nil {
+				return nil, err
+			}
+
+			left = &Expr{Op: OpAnd, Left: left, Right: right}
+		}
+	}
+}
+
+func (p *Parser) parseNot() (*Expr, error) {
+	if p.peek().Type == TokenNot {
+		p.advance()
+
+		right, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+
+		return &Expr{Op: OpNot, Left: right}, nil
+	}
+
+	return p.parsePrimary()
 }
 
-func (p *Parser) Parse() {
-	tokens := p.lexer.Tokens()
+func (p *Parser) parsePrimary() (*Expr, error) {
+	t := p.peek()
+
+	switch t.Type {
+	case TokenLParen:
+		p.advance()
 
-	for _, t := range tokens {
-		p := Expr{
-			Op:    t.Type,
-			Left:  nil,
-			Right: nil,
-			Val:   t.Value,
+		expr, err := p.parseOr()
+		if err != nil {
+			return nil, err
 		}
 
-		fmt.Println(t)
-		fmt.Println(p)
+		if p.peek().Type != TokenRParen {
+			return nil, fmt.Errorf("parser: expected closing parenthesis")
+		}
+		p.advance()
+
+		return expr, nil
+	case TokenTerm:
+		p.advance()
+		return &Expr{Op: OpTerm, Val: t.Value}, nil
+	case TokenPhrase:
+		p.advance()
+		return &Expr{Op: OpPhrase, Val: t.Value}, nil
+	case TokenFuzzy:
+		p.advance()
+		return &Expr{Op: OpFuzzy, Val: t.Value}, nil
+	case TokenRegex:
+		p.advance()
+		return &Expr{Op: OpRegex, Val: t.Value}, nil
+	case TokenField:
+		p.advance()
+		return &Expr{Op: OpField, Field: t.Field, Val: t.Value}, nil
+	default:
+		return nil, fmt.Errorf("parser: unexpected token %q", t.Value)
 	}
 }
+
+// peek returns the current token without consuming it, or a TokenEOF
+// sentinel once the end of the stream has been reached.
+func (p *Parser) peek() Token {
+	if p.pos >= len(p.tokens) {
+		return Token{Type: TokenEOF}
+	}
+
+	return p.tokens[p.pos]
+}
+
+func (p *Parser) advance() {
+	p.pos++
+}
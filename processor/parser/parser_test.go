@@ -0,0 +1,125 @@
+// SPDX-License-Identifier: MIT
+// SPDX-License-Identifier: Unlicense
+
+package parser
+
+import "testing"
+
+func mustParse(t *testing.T, query string) *Expr {
+	t.Helper()
+
+	p := NewParser(NewLexer(query))
+	expr, err := p.Parse()
+	if err != nil {
+		t.Fatalf("unexpected error parsing %q: %v", query, err)
+	}
+
+	return expr
+}
+
+func TestParsePrecedenceAndBeforeOr(t *testing.T) {
+	// a AND b OR c should parse as (a AND b) OR c
+	expr := mustParse(t, "a AND b OR c")
+
+	if expr.Op != OpOr {
+		t.Fatalf("expected top level OR, got %s", expr.Op)
+	}
+
+	if expr.Left.Op != OpAnd || expr.Left.Left.Val != "a" || expr.Left.Right.Val != "b" {
+		t.Errorf("expected left hand side to be (a AND b), got %+v", expr.Left)
+	}
+
+	if expr.Right.Val != "c" {
+		t.Errorf("expected right hand side to be c, got %+v", expr.Right)
+	}
+}
+
+func TestParseImplicitAnd(t *testing.T) {
+	expr := mustParse(t, "foo bar")
+
+	if expr.Op != OpAnd || expr.Left.Val != "foo" || expr.Right.Val != "bar" {
+		t.Errorf("expected implicit AND of foo and bar, got %+v", expr)
+	}
+}
+
+func TestParseGrouping(t *testing.T) {
+	expr := mustParse(t, "(a OR b) AND c")
+
+	if expr.Op != OpAnd {
+		t.Fatalf("expected top level AND, got %s", expr.Op)
+	}
+
+	if expr.Left.Op != OpOr {
+		t.Errorf("expected grouped OR on the left, got %s", expr.Left.Op)
+	}
+}
+
+func TestParseNot(t *testing.T) {
+	expr := mustParse(t, "NOT foo")
+
+	if expr.Op != OpNot || expr.Left.Val != "foo" {
+		t.Errorf("expected NOT foo, got %+v", expr)
+	}
+}
+
+func TestParseMixedClauses(t *testing.T) {
+	expr := mustParse(t, `"foo bar" AND /baz.*/ OR qux~`)
+
+	if expr.Op != OpOr {
+		t.Fatalf("expected top level OR, got %s", expr.Op)
+	}
+
+	and := expr.Left
+	if and.Op != OpAnd || and.Left.Op != OpPhrase || and.Left.Val != "foo bar" {
+		t.Errorf("expected left AND operand to be phrase \"foo bar\", got %+v", and.Left)
+	}
+
+	if and.Right.Op != OpRegex || and.Right.Val != "baz.*" {
+		t.Errorf("expected right AND operand to be regex baz.*, got %+v", and.Right)
+	}
+
+	if expr.Right.Op != OpFuzzy || expr.Right.Val != "qux" {
+		t.Errorf("expected fuzzy qux on the right, got %+v", expr.Right)
+	}
+}
+
+func TestParseFieldScopedTerms(t *testing.T) {
+	expr := mustParse(t, "ext:go AND path:vendor")
+
+	if expr.Left.Op != OpField || expr.Left.Field != "ext" || expr.Left.Val != "go" {
+		t.Errorf("unexpected field clause: %+v", expr.Left)
+	}
+
+	if expr.Right.Op != OpField || expr.Right.Field != "path" || expr.Right.Val != "vendor" {
+		t.Errorf("unexpected field clause: %+v", expr.Right)
+	}
+}
+
+func TestParseSizeComparison(t *testing.T) {
+	expr := mustParse(t, "size:>1000")
+
+	if expr.Op != OpField || expr.Field != "size" || expr.Val != ">1000" {
+		t.Errorf("unexpected size clause: %+v", expr)
+	}
+}
+
+func TestParseEmptyQuery(t *testing.T) {
+	p := NewParser(NewLexer(""))
+	if _, err := p.Parse(); err == nil {
+		t.Error("expected an error for an empty query")
+	}
+}
+
+func TestParseUnbalancedParens(t *testing.T) {
+	p := NewParser(NewLexer("(a AND b"))
+	if _, err := p.Parse(); err == nil {
+		t.Error("expected an error for unbalanced parentheses")
+	}
+}
+
+func TestParseTrailingTokens(t *testing.T) {
+	p := NewParser(NewLexer("a)"))
+	if _, err := p.Parse(); err == nil {
+		t.Error("expected an error for a trailing unmatched parenthesis")
+	}
+}
@@ -0,0 +1,194 @@
+// SPDX-License-Identifier: MIT OR Unlicense
+
+package processor
+
+import (
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/boyter/cs/processor/parser"
+)
+
+// CompileQuery parses a raw user supplied query string into the AST
+// consumed by EvalQuery. It is a thin wrapper around the parser package
+// that gives the rest of processor a single entry point, and keeps the
+// parser package free of any dependency on fileJob.
+func CompileQuery(query string) (*parser.Expr, error) {
+	p := parser.NewParser(parser.NewLexer(query))
+	return p.Parse()
+}
+
+// EvalQuery reports whether res satisfies expr. It is called once per
+// file by the searcher in place of the old flat space-separated term
+// list, which means a file is only considered a match when its content
+// and metadata satisfy the full boolean expression rather than merely
+// containing one of the terms.
+//
+// AND and OR both short circuit: the right hand side of an AND is never
+// evaluated once the left hand side is false, and likewise for OR once
+// the left hand side is true. This matters because regex and fuzzy
+// clauses are comparatively expensive to evaluate.
+func EvalQuery(expr *parser.Expr, res *fileJob) bool {
+	if expr == nil {
+		return true
+	}
+
+	switch expr.Op {
+	case parser.OpAnd:
+		return EvalQuery(expr.Left, res) && EvalQuery(expr.Right, res)
+	case parser.OpOr:
+		return EvalQuery(expr.Left, res) || EvalQuery(expr.Right, res)
+	case parser.OpNot:
+		return !EvalQuery(expr.Left, res)
+	case parser.OpTerm, parser.OpPhrase:
+		return strings.Contains(strings.ToLower(string(res.Content)), strings.ToLower(expr.Val))
+	case parser.OpFuzzy:
+		return evalFuzzy(string(res.Content), expr.Val)
+	case parser.OpRegex:
+		re, err := regexp.Compile(expr.Val)
+		if err != nil {
+			return false
+		}
+		return re.Match(res.Content)
+	case parser.OpField:
+		return evalField(expr.Field, expr.Val, res)
+	}
+
+	return false
+}
+
+// evalFuzzy reports whether any whitespace delimited word in content is
+// within a single insertion, deletion, substitution or adjacent
+// transposition of term, which is the same tolerance implied by the `~` suffix at the query language
+// level (e.g. `gerp~` should still find "grep").
+func evalFuzzy(content string, term string) bool {
+	term = strings.ToLower(term)
+
+	for _, word := range strings.Fields(strings.ToLower(content)) {
+		if levenshteinWithin(word, term, 1) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// levenshteinWithin reports whether the (restricted) Damerau-Levenshtein
+// distance between a and b is at most max, bailing out early once it is
+// clear that it cannot be. Adjacent transpositions (e.g. "gerp" -> "grep")
+// count as a single edit rather than two, matching what the `~` fuzzy
+// operator's users expect from a typo.
+func levenshteinWithin(a, b string, max int) bool {
+	if abs(len(a)-len(b)) > max {
+		return false
+	}
+
+	ra, rb := []rune(a), []rune(b)
+	prev2 := make([]int, len(rb)+1)
+	prev := make([]int, len(rb)+1)
+	curr := make([]int, len(rb)+1)
+
+	for j := range prev {
+		prev[j] = j
+	}
+
+	for i := 1; i <= len(ra); i++ {
+		curr[0] = i
+		rowMin := curr[0]
+
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+
+			curr[j] = min3(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+
+			if i > 1 && j > 1 && ra[i-1] == rb[j-2] && ra[i-2] == rb[j-1] {
+				if t := prev2[j-2] + 1; t < curr[j] {
+					curr[j] = t
+				}
+			}
+
+			if curr[j] < rowMin {
+				rowMin = curr[j]
+			}
+		}
+
+		if rowMin > max {
+			return false
+		}
+
+		prev2, prev, curr = prev, curr, prev2
+	}
+
+	return prev[len(rb)] <= max
+}
+
+func abs(v int) int {
+	if v < 0 {
+		return -v
+	}
+	return v
+}
+
+func min3(a, b, c int) int {
+	m := a
+	if b < m {
+		m = b
+	}
+	if c < m {
+		m = c
+	}
+	return m
+}
+
+// evalField evaluates a single field:value clause such as ext:go,
+// path:vendor or size:>1000 against res.
+func evalField(field string, value string, res *fileJob) bool {
+	switch strings.ToLower(field) {
+	case "ext":
+		ext := strings.TrimPrefix(filepath.Ext(res.Filename), ".")
+		return strings.EqualFold(ext, value)
+	case "path":
+		return strings.Contains(strings.ToLower(res.Location), strings.ToLower(value))
+	case "size":
+		return evalSizeComparison(value, res.Bytes)
+	default:
+		return strings.Contains(strings.ToLower(string(res.Content)), strings.ToLower(value))
+	}
+}
+
+// evalSizeComparison parses a value such as ">1000", "<=512" or "1000"
+// (which is treated as ==) and compares it against actual.
+func evalSizeComparison(value string, actual int) bool {
+	op := "=="
+
+	for _, candidate := range []string{">=", "<=", ">", "<", "="} {
+		if strings.HasPrefix(value, candidate) {
+			op = candidate
+			value = strings.TrimPrefix(value, candidate)
+			break
+		}
+	}
+
+	want, err := strconv.Atoi(value)
+	if err != nil {
+		return false
+	}
+
+	switch op {
+	case ">":
+		return actual > want
+	case ">=":
+		return actual >= want
+	case "<":
+		return actual < want
+	case "<=":
+		return actual <= want
+	default:
+		return actual == want
+	}
+}
@@ -0,0 +1,110 @@
+// SPDX-License-Identifier: MIT
+// SPDX-License-Identifier: Unlicense
+
+package processor
+
+import "testing"
+
+func TestCompileAndEvalQueryPrecedence(t *testing.T) {
+	// a AND b OR c: a file containing only "c" should match even though
+	// it has neither "a" nor "b".
+	expr, err := CompileQuery("a AND b OR c")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	res := &fileJob{Content: []byte("this file only has c in it")}
+
+	if !EvalQuery(expr, res) {
+		t.Error("expected query to match on the OR clause")
+	}
+}
+
+func TestEvalQueryShortCircuitsAnd(t *testing.T) {
+	expr, err := CompileQuery("missing AND /[/")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	res := &fileJob{Content: []byte("nothing relevant here")}
+
+	// The left hand side of the AND is false, so the invalid regex on
+	// the right is never compiled/evaluated and EvalQuery returns false.
+	// An invalid regex that was evaluated would also yield false, so this
+	// checks the result of the AND rather than observing the skip itself.
+	if EvalQuery(expr, res) {
+		t.Error("expected false when the left hand side of an AND does not match")
+	}
+}
+
+func TestEvalQueryNot(t *testing.T) {
+	expr, err := CompileQuery("NOT vendor")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if !EvalQuery(expr, &fileJob{Content: []byte("clean")}) {
+		t.Error("expected NOT vendor to match a file without vendor")
+	}
+
+	if EvalQuery(expr, &fileJob{Content: []byte("this has vendor in it")}) {
+		t.Error("expected NOT vendor to reject a file containing vendor")
+	}
+}
+
+func TestEvalQueryFieldExt(t *testing.T) {
+	expr, err := CompileQuery("ext:go")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if !EvalQuery(expr, &fileJob{Filename: "main.go"}) {
+		t.Error("expected ext:go to match main.go")
+	}
+
+	if EvalQuery(expr, &fileJob{Filename: "main.rs"}) {
+		t.Error("expected ext:go to reject main.rs")
+	}
+}
+
+func TestEvalQueryFieldSize(t *testing.T) {
+	expr, err := CompileQuery("size:>1000")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if !EvalQuery(expr, &fileJob{Bytes: 2000}) {
+		t.Error("expected size:>1000 to match a 2000 byte file")
+	}
+
+	if EvalQuery(expr, &fileJob{Bytes: 500}) {
+		t.Error("expected size:>1000 to reject a 500 byte file")
+	}
+}
+
+func TestEvalQueryFuzzy(t *testing.T) {
+	expr, err := CompileQuery("gerp~")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if !EvalQuery(expr, &fileJob{Content: []byte("run grep over the tree")}) {
+		t.Error("expected gerp~ to fuzzy match grep")
+	}
+}
+
+func TestEvalQueryMixedClauses(t *testing.T) {
+	expr, err := CompileQuery(`ext:go AND ("needle" OR /hay.*stack/)`)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	res := &fileJob{
+		Filename: "main.go",
+		Content:  []byte("looking for a haystack of content"),
+	}
+
+	if !EvalQuery(expr, res) {
+		t.Error("expected mixed field/phrase/regex query to match")
+	}
+}
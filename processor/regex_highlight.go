@@ -0,0 +1,84 @@
+// SPDX-License-Identifier: MIT OR Unlicense
+
+package processor
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// regexColorPalette cycles through a handful of distinct ANSI colours so
+// that, in --regex mode, each expression (one per alternation supplied
+// via repeated -e flags) gets its own highlight colour instead of
+// everything being highlighted identically.
+var regexColorPalette = []string{
+	"\033[1;31m", // red
+	"\033[1;32m", // green
+	"\033[1;33m", // yellow
+	"\033[1;34m", // blue
+	"\033[1;35m", // magenta
+	"\033[1;36m", // cyan
+}
+
+// parseRegexMatchKey recovers the expression index encoded by
+// regexMatchKey, reporting ok=false for any key that RegexSearcher did
+// not produce (e.g. an ordinary term match from a non-regex search).
+func parseRegexMatchKey(key string) (int, bool) {
+	if !strings.HasPrefix(key, "regex") {
+		return 0, false
+	}
+
+	rest := strings.TrimPrefix(key, "regex")
+
+	colon := strings.IndexByte(rest, ':')
+	if colon == -1 {
+		return 0, false
+	}
+
+	idx, err := strconv.Atoi(rest[:colon])
+	if err != nil {
+		return 0, false
+	}
+
+	return idx, true
+}
+
+// highlightMultiColor inserts an ANSI colour from regexColorPalette
+// before each location and fmtEnd immediately after, chosen by each
+// location's expression index modulo the palette length. It exists
+// alongside (rather than inside) str.HighlightString because that
+// helper only knows about a single highlight colour.
+//
+// locations is [start, end, expressionIndex] triples; overlapping or
+// out of range locations are skipped rather than corrupting the output.
+func highlightMultiColor(content string, locations [][3]int, fmtEnd string) string {
+	if len(locations) == 0 {
+		return content
+	}
+
+	sort.Slice(locations, func(i, j int) bool {
+		return locations[i][0] < locations[j][0]
+	})
+
+	var b strings.Builder
+	pos := 0
+
+	for _, loc := range locations {
+		start, end, colorIdx := loc[0], loc[1], loc[2]
+
+		if start < pos || start < 0 || end > len(content) || start >= end {
+			continue
+		}
+
+		b.WriteString(content[pos:start])
+		b.WriteString(regexColorPalette[colorIdx%len(regexColorPalette)])
+		b.WriteString(content[start:end])
+		b.WriteString(fmtEnd)
+		pos = end
+	}
+
+	b.WriteString(content[pos:])
+
+	return b.String()
+}
@@ -0,0 +1,67 @@
+// SPDX-License-Identifier: MIT OR Unlicense
+
+package processor
+
+import (
+	"fmt"
+	"regexp"
+)
+
+// DefaultMaxMatchesPerFile caps how many matches a single expression can
+// contribute to one file's MatchLocations, the same way godoc's full
+// text regex search bounds memory use against pathological patterns
+// (e.g. `.*` over a large file).
+const DefaultMaxMatchesPerFile = 10000
+
+// RegexSearcher runs one or more regular expressions -- the query itself
+// in --regex mode, or one per repeated -e flag -- against a fileJob's
+// Content and records every match into its MatchLocations. Matches are
+// keyed as "regexN:<matched text>" rather than just the matched text, so
+// that two expressions matching the same substring are still accounted
+// for as distinct terms: this is what lets snippet ranking keep
+// rewarding co-occurrence of distinct expressions, and lets formatDefault
+// give each expression its own highlight colour.
+type RegexSearcher struct {
+	Expressions       []*regexp.Regexp
+	MaxMatchesPerFile int
+}
+
+// NewRegexSearcher builds a RegexSearcher over expressions, falling back
+// to DefaultMaxMatchesPerFile when maxMatchesPerFile is not positive.
+func NewRegexSearcher(expressions []*regexp.Regexp, maxMatchesPerFile int) RegexSearcher {
+	if maxMatchesPerFile <= 0 {
+		maxMatchesPerFile = DefaultMaxMatchesPerFile
+	}
+
+	return RegexSearcher{
+		Expressions:       expressions,
+		MaxMatchesPerFile: maxMatchesPerFile,
+	}
+}
+
+// Search runs every configured expression against res.Content, merging
+// matches into res.MatchLocations, and reports whether at least one
+// expression matched at all.
+func (r RegexSearcher) Search(res *fileJob) bool {
+	matched := false
+
+	if res.MatchLocations == nil {
+		res.MatchLocations = map[string][][]int{}
+	}
+
+	for idx, expr := range r.Expressions {
+		for _, loc := range expr.FindAllIndex(res.Content, r.MaxMatchesPerFile) {
+			key := regexMatchKey(idx, string(res.Content[loc[0]:loc[1]]))
+			res.MatchLocations[key] = append(res.MatchLocations[key], []int{loc[0], loc[1]})
+			matched = true
+		}
+	}
+
+	return matched
+}
+
+// regexMatchKey builds the MatchLocations key for a match produced by
+// the expression at index idx.
+func regexMatchKey(idx int, text string) string {
+	return fmt.Sprintf("regex%d:%s", idx, text)
+}
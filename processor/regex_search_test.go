@@ -0,0 +1,81 @@
+// SPDX-License-Identifier: MIT
+// SPDX-License-Identifier: Unlicense
+
+package processor
+
+import (
+	"regexp"
+	"strings"
+	"testing"
+)
+
+func TestRegexSearcherRecordsMatchesPerExpression(t *testing.T) {
+	searcher := NewRegexSearcher([]*regexp.Regexp{
+		regexp.MustCompile(`foo`),
+		regexp.MustCompile(`bar`),
+	}, 0)
+
+	res := &fileJob{Content: []byte("foo and bar and foo again")}
+
+	if !searcher.Search(res) {
+		t.Fatal("expected at least one match")
+	}
+
+	if len(res.MatchLocations["regex0:foo"]) != 2 {
+		t.Errorf("expected 2 matches for expression 0, got %d", len(res.MatchLocations["regex0:foo"]))
+	}
+
+	if len(res.MatchLocations["regex1:bar"]) != 1 {
+		t.Errorf("expected 1 match for expression 1, got %d", len(res.MatchLocations["regex1:bar"]))
+	}
+}
+
+func TestRegexSearcherNoMatch(t *testing.T) {
+	searcher := NewRegexSearcher([]*regexp.Regexp{regexp.MustCompile(`zzz`)}, 0)
+	res := &fileJob{Content: []byte("nothing relevant")}
+
+	if searcher.Search(res) {
+		t.Error("expected no match")
+	}
+}
+
+func TestRegexSearcherCapsMatchesPerFile(t *testing.T) {
+	searcher := NewRegexSearcher([]*regexp.Regexp{regexp.MustCompile(`a`)}, 3)
+
+	res := &fileJob{Content: []byte("aaaaaaaaaa")}
+	searcher.Search(res)
+
+	if len(res.MatchLocations["regex0:a"]) != 3 {
+		t.Errorf("expected matches to be capped at 3, got %d", len(res.MatchLocations["regex0:a"]))
+	}
+}
+
+func TestParseRegexMatchKey(t *testing.T) {
+	idx, ok := parseRegexMatchKey("regex2:needle")
+	if !ok || idx != 2 {
+		t.Errorf("expected idx=2 ok=true, got idx=%d ok=%v", idx, ok)
+	}
+
+	if _, ok := parseRegexMatchKey("needle"); ok {
+		t.Error("expected a plain term key not to parse as a regex key")
+	}
+}
+
+func TestHighlightMultiColorAssignsDistinctColors(t *testing.T) {
+	out := highlightMultiColor("foo bar", [][3]int{{0, 3, 0}, {4, 7, 1}}, "\033[0m")
+
+	if out == "foo bar" {
+		t.Fatal("expected highlight codes to be inserted")
+	}
+
+	if len(regexColorPalette) < 2 {
+		t.Fatal("palette needs at least 2 colours for this test to be meaningful")
+	}
+
+	wantFirst := regexColorPalette[0]
+	wantSecond := regexColorPalette[1]
+
+	if !strings.Contains(out, wantFirst) || !strings.Contains(out, wantSecond) {
+		t.Errorf("expected both palette colours present, got %q", out)
+	}
+}
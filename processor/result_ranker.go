@@ -0,0 +1,216 @@
+// SPDX-License-Identifier: MIT OR Unlicense
+
+package processor
+
+import (
+	"fmt"
+	"math"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// bm25K1 and bm25B are the usual Okapi BM25 tuning constants. k1 controls
+// how quickly additional term frequency saturates, b controls how much
+// document length normalises the score.
+const (
+	bm25K1 = 1.2
+	bm25B  = 0.75
+)
+
+// locationBoostWeight is added to a result's Score for every matched term
+// that also appears in its filename, on the theory that a hit in the
+// filename itself is usually more relevant than one buried in content.
+const locationBoostWeight = 0.1
+
+// rankResults scores and sorts results using the combination of rankers
+// appropriate for algorithm, which should be one of "v1" (the default
+// TF-IDF + location based scoring), "v2" (the fzf inspired path bonus
+// ranker layered on top of v1) or "bm25" (Okapi BM25 in place of TF-IDF,
+// still combined with the location boost). totalFileCount is the number
+// of files that were searched, not just the number that matched, and is
+// needed to compute inverse document frequency.
+func rankResults(totalFileCount int, results []*fileJob, algorithm string, query string, bonusWeight float64) []*fileJob {
+	results = rankResultsWordCount(results)
+
+	if algorithm == "bm25" {
+		results = rankResultsBM25(totalFileCount, results)
+	} else {
+		results = rankResultsTFIDF(totalFileCount, results)
+	}
+
+	results = rankResultsLocation(results)
+
+	if algorithm == "v2" {
+		results = rankResultsV2(query, bonusWeight, results)
+	}
+
+	sortResults(results)
+	return results
+}
+
+// rankResultsWordCount adds the raw number of matches found in a result
+// to its Score. This is the simplest possible ranker and mostly exists
+// so that a file with many hits never scores identically to one with a
+// single hit purely because the rarer terms happen to cancel out.
+func rankResultsWordCount(results []*fileJob) []*fileJob {
+	for _, res := range results {
+		var count int
+
+		for _, locations := range res.MatchLocations {
+			count += len(locations)
+		}
+
+		res.Score += float64(count)
+		addExplanation(res, &Explanation{
+			Value:   float64(count),
+			Message: fmt.Sprintf("word count: %d total matches", count),
+		})
+	}
+
+	return results
+}
+
+// rankResultsTFIDF adds a standard TF-IDF score to each result: for every
+// matched term, term frequency in that result multiplied by the inverse
+// document frequency of the term across the whole corpus.
+func rankResultsTFIDF(totalFileCount int, results []*fileJob) []*fileJob {
+	documentFrequency := calculateDocumentTermFrequency(results)
+
+	for _, res := range results {
+		var score float64
+		var terms []string
+
+		for term, locations := range res.MatchLocations {
+			df := documentFrequency[term]
+			if df == 0 {
+				continue
+			}
+
+			tf := float64(len(locations))
+			idf := math.Log(float64(totalFileCount) / float64(df))
+			score += tf * idf
+			terms = append(terms, term)
+		}
+
+		res.Score += score
+		sort.Strings(terms)
+		addExplanation(res, &Explanation{
+			Value:   score,
+			Message: fmt.Sprintf("tf-idf: %.4f = Σ (tf * idf) over terms %v", score, terms),
+		})
+	}
+
+	return results
+}
+
+// rankResultsBM25 adds an Okapi BM25 score to each result using bm25K1
+// and bm25B as the saturation and length normalisation constants.
+func rankResultsBM25(totalFileCount int, results []*fileJob) []*fileJob {
+	documentFrequency := calculateDocumentTermFrequency(results)
+
+	var totalBytes int
+	for _, res := range results {
+		totalBytes += res.Bytes
+	}
+
+	avgBytes := float64(totalBytes) / float64(len(results))
+	if avgBytes == 0 {
+		avgBytes = 1
+	}
+
+	for _, res := range results {
+		var score float64
+		docLen := float64(res.Bytes)
+
+		for term, locations := range res.MatchLocations {
+			df := documentFrequency[term]
+			if df == 0 {
+				continue
+			}
+
+			tf := float64(len(locations))
+			idf := math.Log(1 + (float64(totalFileCount)-float64(df)+0.5)/(float64(df)+0.5))
+			score += idf * (tf * (bm25K1 + 1)) / (tf + bm25K1*(1-bm25B+bm25B*(docLen/avgBytes)))
+		}
+
+		res.Score += score
+		addExplanation(res, &Explanation{
+			Value:   score,
+			Message: fmt.Sprintf("bm25: k1=%.2f b=%.2f score=%.4f", bm25K1, bm25B, score),
+		})
+	}
+
+	return results
+}
+
+// rankResultsLocation rewards a result whenever one of its matched terms
+// also appears in its own filename, since a match in the filename is
+// usually a stronger signal of relevance than the same match buried in
+// the body of the file.
+func rankResultsLocation(results []*fileJob) []*fileJob {
+	for _, res := range results {
+		base := strings.ToLower(filepath.Base(res.Location))
+
+		var boost float64
+		for term := range res.MatchLocations {
+			if term != "" && strings.Contains(base, strings.ToLower(term)) {
+				boost += locationBoostWeight
+			}
+		}
+
+		res.Score += boost
+		if boost > 0 {
+			addExplanation(res, &Explanation{
+				Value:   boost,
+				Message: fmt.Sprintf("location boost: +%.2f because %s matches filename", boost, res.Location),
+			})
+		}
+	}
+
+	return results
+}
+
+// calculateDocumentFrequency sums, across all results, the total number
+// of times each term was matched. Unlike calculateDocumentTermFrequency
+// this counts every occurrence rather than one per file.
+func calculateDocumentFrequency(results []*fileJob) map[string]int {
+	freq := map[string]int{}
+
+	for _, res := range results {
+		for term, locations := range res.MatchLocations {
+			freq[term] += len(locations)
+		}
+	}
+
+	return freq
+}
+
+// calculateDocumentTermFrequency counts, for each term, how many results
+// it appears in at least once. This is the classic IR "document
+// frequency" used by rankResultsTFIDF and rankResultsBM25 to compute
+// inverse document frequency.
+func calculateDocumentTermFrequency(results []*fileJob) map[string]int {
+	freq := map[string]int{}
+
+	for _, res := range results {
+		for term := range res.MatchLocations {
+			freq[term]++
+		}
+	}
+
+	return freq
+}
+
+// sortResults orders results from most to least relevant. Ties are
+// broken on Location so that output ordering is stable between runs
+// rather than depending on map iteration order upstream.
+func sortResults(results []*fileJob) {
+	sort.Slice(results, func(i, j int) bool {
+		if results[i].Score == results[j].Score {
+			return results[i].Location < results[j].Location
+		}
+
+		return results[i].Score > results[j].Score
+	})
+}
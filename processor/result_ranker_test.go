@@ -38,6 +38,61 @@ func TestRankResultsTFIDF(t *testing.T) {
 	}
 }
 
+func TestRankResultsBM25(t *testing.T) {
+	ml1 := map[string][][]int{}
+	ml1["this"] = [][]int{{1}}
+	ml1["is"] = [][]int{{1}}
+	ml1["a"] = [][]int{{1}, {2}}
+	ml1["sample"] = [][]int{{1}}
+
+	ml2 := map[string][][]int{}
+	ml2["this"] = [][]int{{1}}
+	ml2["is"] = [][]int{{1}}
+	ml2["another"] = [][]int{{1}, {2}}
+	ml2["example"] = [][]int{{1}, {2}, {3}}
+
+	s := []*fileJob{
+		{
+			MatchLocations: ml1,
+			Location:       "/test/other.go",
+			Bytes:          12,
+		},
+		{
+			MatchLocations: ml2,
+			Location:       "/test/test.go",
+			Bytes:          12,
+		},
+	}
+
+	s = rankResultsBM25(2, s)
+
+	if s[0].Score > s[1].Score {
+		t.Error("index 0 should have lower score than 1")
+	}
+}
+
+func TestRankResultsBM25ReachableViaAlgorithm(t *testing.T) {
+	ml := map[string][][]int{}
+	ml["example"] = [][]int{{1}, {2}, {3}}
+
+	s := []*fileJob{
+		{
+			MatchLocations: ml,
+			Location:       "/test/test.go",
+			Bytes:          12,
+		},
+	}
+
+	s = rankResults(1, s, "bm25", "example", 0)
+
+	// rankResultsWordCount alone adds 3 for the three matches, and neither
+	// TF-IDF (idf is log(1/1) = 0) nor the location boost add anything
+	// here, so anything above 3 must have come from BM25.
+	if s[0].Score <= 3 {
+		t.Error("expected bm25 algorithm to contribute a positive score")
+	}
+}
+
 func TestRankResultsLocation(t *testing.T) {
 	ml := map[string][][]int{}
 	ml["test"] = [][]int{{1}, {2}, {3}}
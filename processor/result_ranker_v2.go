@@ -0,0 +1,160 @@
+// SPDX-License-Identifier: MIT OR Unlicense
+
+package processor
+
+import (
+	"fmt"
+	"unicode"
+)
+
+// defaultBonusWeight is used when a ResultSummarizer does not set its own
+// BonusWeight, matching the "feels right" default fzf itself ships with
+// for its own bonus weighting.
+const defaultBonusWeight = 0.5
+
+// charClass buckets a rune into the categories rankResultsV2 needs to
+// recognise word boundaries, camelCase transitions and path separators.
+type charClass int
+
+const (
+	classNonWord charClass = iota
+	classLower
+	classUpper
+	classNumber
+	classPathSep
+)
+
+func classify(r rune) charClass {
+	switch {
+	case r == '/' || r == '\\':
+		return classPathSep
+	case unicode.IsUpper(r):
+		return classUpper
+	case unicode.IsLower(r):
+		return classLower
+	case unicode.IsDigit(r):
+		return classNumber
+	default:
+		return classNonWord
+	}
+}
+
+// Bonus weights. bonusConsecutive is multiplied by the length of the run
+// of consecutive matches seen so far, so it compounds the longer a run
+// gets, rewarding contiguous matches like "foo" over scattered ones like
+// "f..o..o".
+const (
+	bonusBoundary    = 2
+	bonusCamelCase   = 1
+	bonusPathSep     = 2
+	bonusConsecutive = 1
+)
+
+// evaluateBonus scores the transition from the character class before a
+// match, prevClass, into the class of the matched character itself, cur.
+func evaluateBonus(prevClass charClass, cur charClass) int {
+	switch {
+	case prevClass == classPathSep:
+		return bonusPathSep
+	case prevClass == classNonWord:
+		return bonusBoundary
+	case prevClass == classLower && cur == classUpper:
+		return bonusCamelCase
+	default:
+		return 0
+	}
+}
+
+// rankResultsV2 implements an fzf "v2" inspired fuzzy ranker over each
+// result's Location. Where rankResultsLocation only checks whether a
+// whole term appears in the filename, this finds query as a fuzzy
+// subsequence of the path and rewards matches that land on natural
+// boundaries (start of path segment, start of word, camelCase
+// transition) as well as runs of consecutive matching characters.
+//
+// bonusWeight is used as given, including zero: ResultSummarizer applies
+// defaultBonusWeight at construction time, so a caller that explicitly
+// wants the v2 bonus to contribute nothing can pass 0 here without it
+// being silently replaced.
+func rankResultsV2(query string, bonusWeight float64, results []*fileJob) []*fileJob {
+	if query == "" {
+		return results
+	}
+
+	for _, res := range results {
+		positions, matched := fuzzyMatchPositions(res.Location, query)
+		if !matched {
+			continue
+		}
+
+		fileBytes := res.Bytes
+		if fileBytes == 0 {
+			fileBytes = 1
+		}
+
+		bonus := pathMatchBonus(res.Location, positions)
+		contribution := bonusWeight * float64(bonus) / float64(fileBytes)
+
+		res.Score += contribution
+		addExplanation(res, &Explanation{
+			Value:   contribution,
+			Message: fmt.Sprintf("v2 path bonus: bonus=%d query=%q weight=%.2f", bonus, query, bonusWeight),
+		})
+	}
+
+	return results
+}
+
+// fuzzyMatchPositions greedily matches pattern against text as a
+// case-insensitive subsequence, returning the index of each matched rune
+// in text and whether every rune in pattern was found at all.
+func fuzzyMatchPositions(text string, pattern string) ([]int, bool) {
+	if pattern == "" {
+		return nil, false
+	}
+
+	runes := []rune(text)
+	patternRunes := []rune(pattern)
+
+	positions := make([]int, 0, len(patternRunes))
+	pi := 0
+
+	for i, r := range runes {
+		if pi >= len(patternRunes) {
+			break
+		}
+
+		if unicode.ToLower(r) == unicode.ToLower(patternRunes[pi]) {
+			positions = append(positions, i)
+			pi++
+		}
+	}
+
+	return positions, pi == len(patternRunes)
+}
+
+// pathMatchBonus sums evaluateBonus over every matched position in text,
+// plus a compounding bonus for each run of consecutive matches.
+func pathMatchBonus(text string, positions []int) int {
+	runes := []rune(text)
+	bonus := 0
+	consecutive := 0
+
+	for i, pos := range positions {
+		prevClass := classPathSep // treat the start of the string as a boundary
+		if pos > 0 {
+			prevClass = classify(runes[pos-1])
+		}
+
+		bonus += evaluateBonus(prevClass, classify(runes[pos]))
+
+		if i > 0 && pos == positions[i-1]+1 {
+			consecutive++
+			bonus += consecutive * bonusConsecutive
+		} else {
+			consecutive = 0
+		}
+	}
+
+	return bonus
+}
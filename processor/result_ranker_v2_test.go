@@ -0,0 +1,71 @@
+// SPDX-License-Identifier: MIT
+// SPDX-License-Identifier: Unlicense
+
+package processor
+
+import "testing"
+
+func TestRankResultsV2FilenameCamelCaseBoundary(t *testing.T) {
+	s := []*fileJob{
+		{Location: "FooBar", Bytes: 6},
+		{Location: "foobar", Bytes: 6},
+	}
+
+	s = rankResultsV2("FB", defaultBonusWeight, s)
+
+	if s[0].Score <= s[1].Score {
+		t.Error("expected FooBar to score higher than foobar for query FB")
+	}
+}
+
+func TestRankResultsV2PathSeparatorBoundary(t *testing.T) {
+	s := []*fileJob{
+		{Location: "src/foo.go", Bytes: 10},
+		{Location: "srcfoo/bar.go", Bytes: 13},
+	}
+
+	s = rankResultsV2("foo", defaultBonusWeight, s)
+
+	if s[0].Score <= s[1].Score {
+		t.Error("expected src/foo.go to outrank srcfoo/bar.go for query foo")
+	}
+}
+
+func TestRankResultsV2NoMatchLeavesScoreUnchanged(t *testing.T) {
+	s := []*fileJob{
+		{Location: "unrelated.go", Bytes: 10, Score: 1.5},
+	}
+
+	s = rankResultsV2("zzz", defaultBonusWeight, s)
+
+	if s[0].Score != 1.5 {
+		t.Error("expected score to be untouched when query does not fuzzy match")
+	}
+}
+
+func TestRankResultsV2ZeroBonusWeightDisablesBonus(t *testing.T) {
+	s := []*fileJob{
+		{Location: "FooBar", Bytes: 6, Score: 1.5},
+	}
+
+	s = rankResultsV2("FB", 0, s)
+
+	if s[0].Score != 1.5 {
+		t.Error("expected an explicit bonusWeight of 0 to contribute nothing, not the default")
+	}
+}
+
+func TestFuzzyMatchPositions(t *testing.T) {
+	positions, matched := fuzzyMatchPositions("FooBar", "FB")
+	if !matched {
+		t.Fatal("expected FB to fuzzy match FooBar")
+	}
+
+	if len(positions) != 2 || positions[0] != 0 || positions[1] != 3 {
+		t.Errorf("unexpected match positions: %v", positions)
+	}
+
+	if _, matched := fuzzyMatchPositions("FooBar", "xyz"); matched {
+		t.Error("expected xyz not to fuzzy match FooBar")
+	}
+}
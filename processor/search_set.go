@@ -0,0 +1,178 @@
+// SPDX-License-Identifier: MIT OR Unlicense
+
+package processor
+
+import (
+	"bytes"
+	"encoding/gob"
+	"hash/fnv"
+	"io/ioutil"
+	"math"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// DefaultNGramSize is the n-gram size SearchSet uses unless told
+// otherwise. 3 (trigrams) is the classic choice used by Google's
+// license classifier, the project this "searchset" idea is borrowed
+// from.
+const DefaultNGramSize = 3
+
+// SearchSetSidecarSuffix is appended to a file's own path to build the
+// location --index persists its SearchSet to on disk.
+const SearchSetSidecarSuffix = ".cssearchset"
+
+var wordSplitter = regexp.MustCompile(`\s+`)
+
+// SearchSet is a precomputed index over a single file's content that
+// lets repeated queries -- against the HTTP server in particular --
+// skip the real exact/fuzzy matcher on files that obviously cannot
+// satisfy the query, instead of re-scanning every file on every
+// request.
+//
+// It tokenizes content into overlapping, normalized word n-grams
+// (trigrams by default), hashes each into Positions, and keeps Hashes
+// sorted so a query's n-gram hashes can be intersected against it in a
+// single linear merge.
+type SearchSet struct {
+	NGramSize int
+	Positions map[uint64][]int
+	Hashes    []uint64
+}
+
+// BuildSearchSet tokenizes content into lower-cased, whitespace
+// separated words, forms overlapping n-grams of size n
+// (DefaultNGramSize when n <= 0) over those words, and hashes each one
+// into the returned SearchSet.
+func BuildSearchSet(content []byte, n int) *SearchSet {
+	if n <= 0 {
+		n = DefaultNGramSize
+	}
+
+	words := normalizeWords(string(content))
+
+	set := &SearchSet{
+		NGramSize: n,
+		Positions: map[uint64][]int{},
+	}
+
+	for i := 0; i+n <= len(words); i++ {
+		h := hashNGram(words[i : i+n])
+		set.Positions[h] = append(set.Positions[h], i)
+	}
+
+	set.Hashes = make([]uint64, 0, len(set.Positions))
+	for h := range set.Positions {
+		set.Hashes = append(set.Hashes, h)
+	}
+
+	sort.Slice(set.Hashes, func(i, j int) bool { return set.Hashes[i] < set.Hashes[j] })
+
+	return set
+}
+
+// QueryHashes builds the sorted set of n-gram hashes for a raw query
+// string, using the same tokenisation and n-gram size BuildSearchSet
+// uses for file content, ready to be passed to IsCandidate.
+func QueryHashes(query string, n int) []uint64 {
+	return BuildSearchSet([]byte(query), n).Hashes
+}
+
+func normalizeWords(content string) []string {
+	content = strings.ToLower(strings.TrimSpace(content))
+	if content == "" {
+		return nil
+	}
+
+	return wordSplitter.Split(content, -1)
+}
+
+func hashNGram(words []string) uint64 {
+	h := fnv.New64a()
+
+	for i, w := range words {
+		if i > 0 {
+			h.Write([]byte{' '})
+		}
+		h.Write([]byte(w))
+	}
+
+	return h.Sum64()
+}
+
+// IsCandidate reports whether this SearchSet shares at least
+// ceil(len(queryHashes) * (1 - maxErrorRate)) hashes with queryHashes,
+// which is the minimum n-gram overlap a file must have before it is
+// worth running the real matcher against. maxErrorRate is clamped to
+// [0, 1]; a query with no hashes at all (e.g. shorter than NGramSize)
+// always matches since there is nothing to rule it out with.
+func (s *SearchSet) IsCandidate(queryHashes []uint64, maxErrorRate float64) bool {
+	if len(queryHashes) == 0 {
+		return true
+	}
+
+	if maxErrorRate < 0 {
+		maxErrorRate = 0
+	} else if maxErrorRate > 1 {
+		maxErrorRate = 1
+	}
+
+	need := int(math.Ceil(float64(len(queryHashes)) * (1 - maxErrorRate)))
+	if need <= 0 {
+		return true
+	}
+
+	var found, si, qi int
+	for si < len(s.Hashes) && qi < len(queryHashes) {
+		switch {
+		case s.Hashes[si] == queryHashes[qi]:
+			found++
+			if found >= need {
+				return true
+			}
+			si++
+			qi++
+		case s.Hashes[si] < queryHashes[qi]:
+			si++
+		default:
+			qi++
+		}
+	}
+
+	return found >= need
+}
+
+// SearchSetSidecarPath returns the path --index persists filePath's
+// SearchSet under.
+func SearchSetSidecarPath(filePath string) string {
+	return filePath + SearchSetSidecarSuffix
+}
+
+// SaveSearchSet gob-encodes set to path, so that --index can amortize
+// the cost of building it across repeated cs invocations and HTTP
+// server queries against a stable corpus.
+func SaveSearchSet(path string, set *SearchSet) error {
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(set); err != nil {
+		return err
+	}
+
+	return ioutil.WriteFile(path, buf.Bytes(), 0600)
+}
+
+// LoadSearchSet reads back a SearchSet previously written by
+// SaveSearchSet.
+func LoadSearchSet(path string) (*SearchSet, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var set SearchSet
+	if err := gob.NewDecoder(bytes.NewReader(data)).Decode(&set); err != nil {
+		return nil, err
+	}
+
+	return &set, nil
+}
@@ -0,0 +1,77 @@
+// SPDX-License-Identifier: MIT
+// SPDX-License-Identifier: Unlicense
+
+package processor
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestBuildSearchSetFindsSharedTrigrams(t *testing.T) {
+	set := BuildSearchSet([]byte("the quick brown fox jumps over the lazy dog"), 3)
+	query := QueryHashes("the quick brown", 3)
+
+	if !set.IsCandidate(query, 0) {
+		t.Error("expected exact trigram overlap to be a candidate at maxErrorRate 0")
+	}
+}
+
+func TestSearchSetRejectsUnrelatedContent(t *testing.T) {
+	set := BuildSearchSet([]byte("completely unrelated file content"), 3)
+	query := QueryHashes("the quick brown fox jumps", 3)
+
+	if set.IsCandidate(query, 0) {
+		t.Error("expected unrelated content not to be a candidate at maxErrorRate 0")
+	}
+}
+
+func TestSearchSetToleratesErrorRate(t *testing.T) {
+	set := BuildSearchSet([]byte("the quick brown fox jumps over a lazy dog"), 3)
+	query := QueryHashes("the quick brown fox jumps over the lazy dog", 3)
+
+	if !set.IsCandidate(query, 0.5) {
+		t.Error("expected a mostly-overlapping query to be a candidate at maxErrorRate 0.5")
+	}
+}
+
+func TestSearchSetEmptyQueryIsAlwaysCandidate(t *testing.T) {
+	set := BuildSearchSet([]byte("anything at all"), 3)
+
+	if !set.IsCandidate(nil, 0) {
+		t.Error("expected an empty query to always be a candidate")
+	}
+}
+
+func TestSaveAndLoadSearchSetRoundTrips(t *testing.T) {
+	original := BuildSearchSet([]byte("round trip through gob encoding"), 3)
+	path := filepath.Join(t.TempDir(), "content.go"+SearchSetSidecarSuffix)
+
+	if err := SaveSearchSet(path, original); err != nil {
+		t.Fatalf("unexpected error saving: %v", err)
+	}
+
+	loaded, err := LoadSearchSet(path)
+	if err != nil {
+		t.Fatalf("unexpected error loading: %v", err)
+	}
+
+	if len(loaded.Hashes) != len(original.Hashes) {
+		t.Fatalf("expected %d hashes, got %d", len(original.Hashes), len(loaded.Hashes))
+	}
+
+	for i, h := range original.Hashes {
+		if loaded.Hashes[i] != h {
+			t.Errorf("hash %d mismatch: want %d got %d", i, h, loaded.Hashes[i])
+		}
+	}
+}
+
+func TestSearchSetSidecarPath(t *testing.T) {
+	got := SearchSetSidecarPath("/tmp/example.go")
+	want := "/tmp/example.go" + SearchSetSidecarSuffix
+
+	if got != want {
+		t.Errorf("expected %q, got %q", want, got)
+	}
+}
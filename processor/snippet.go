@@ -2,6 +2,7 @@ package processor
 
 import (
 	"math"
+	"path/filepath"
 	"sort"
 	"strings"
 	"unicode"
@@ -500,6 +501,306 @@ func extractSnippets(fulltext string, locations [][]int, relLength int, indicato
 	}
 }
 
+// selectSnippets extracts Snippets from res using algo, which should be
+// one of "v1", "v2", "v3" or "v4" (the default, and the only one able to
+// return more than one Snippet). It exists so that callers such as
+// ResultSummarizer do not need to know which extractor returns a single
+// Snippet versus a slice of them, and so that --snippet-algo can keep
+// v1-v3 reachable for comparison/compatibility once v4 is the default.
+func selectSnippets(res *fileJob, df map[string]int, relLength int, indicator string, algo string) []Snippet {
+	switch algo {
+	case "v1":
+		return []Snippet{extractRelevantV1(string(res.Content), matchLocationSlice(res), relLength, indicator)}
+	case "v2":
+		return []Snippet{extractRelevantV2(string(res.Content), matchLocationSlice(res), relLength, indicator)}
+	case "v3":
+		return []Snippet{extractRelevantV3(res, df, relLength, indicator)}
+	default:
+		return extractRelevantV4(res, df, relLength, indicator)
+	}
+}
+
+// matchLocationSlice flattens res.MatchLocations into the [][]int shape
+// that extractRelevantV1 and extractRelevantV2 expect, from back before
+// fileJob kept locations in a map keyed by the term that produced them.
+func matchLocationSlice(res *fileJob) [][]int {
+	var locations [][]int
+
+	for _, v := range res.MatchLocations {
+		locations = append(locations, v...)
+	}
+
+	return locations
+}
+
+// snippetUnit is a single non-overlapping candidate extract identified by
+// segmentUnits: a block of contiguous non-blank lines for source code, or
+// a sentence for prose. Start and End are always byte offsets that land
+// on a unit boundary, never mid-line or mid-sentence.
+type snippetUnit struct {
+	Start int
+	End   int
+}
+
+// Weights for the non tf-idf terms of scoreUnit's formula. These were
+// picked by feel rather than any formal tuning; distinct terms and an
+// early position in the file are both treated as reasonably strong
+// signals, while the length penalty is deliberately mild so a single
+// long, highly relevant block isn't rejected in favour of two short,
+// weaker ones.
+const (
+	snippetDistinctTermWeight  = 0.5
+	snippetEarlyPositionWeight = 0.25
+	snippetLengthPenaltyWeight = 0.1
+
+	// snippetMaxUnits caps how many non-overlapping units extractRelevantV4
+	// will ever return; SnippetCount further trims this down at the
+	// ResultSummarizer layer.
+	snippetMaxUnits = 5
+)
+
+// proseExtensions lists the file extensions segmentUnits treats as prose
+// (split on sentences) rather than source code (split on line blocks).
+var proseExtensions = map[string]bool{
+	".md":   true,
+	".mdx":  true,
+	".txt":  true,
+	".rst":  true,
+	".adoc": true,
+}
+
+// extractRelevantV4 is a unified replacement for extractRelevantV1
+// through extractRelevantV3. Rather than sliding a fixed size window
+// around the text, it first segments res.Content into non-overlapping
+// "units" -- contiguous blocks of non-blank lines for source code, or
+// sentences for prose-like extensions -- scores each unit, then greedily
+// selects the best-scoring, non-overlapping units up to relLength each.
+//
+// Because every unit boundary is a line (or sentence) boundary, the
+// returned Snippets' StartPos/EndPos always land cleanly there, which
+// also fixes the highlight-offset bug the old extractors had where a
+// match span could straddle a cut point introduced by findNearbySpace.
+func extractRelevantV4(res *fileJob, df map[string]int, relLength int, indicator string) []Snippet {
+	content := string(res.Content)
+
+	if len(content) <= relLength {
+		return []Snippet{{Content: content, StartPos: 0, EndPos: len(content)}}
+	}
+
+	units := segmentUnits(res.Filename, content, relLength)
+	if len(units) == 0 {
+		return []Snippet{{Content: content, StartPos: 0, EndPos: len(content)}}
+	}
+
+	type scoredUnit struct {
+		snippetUnit
+		Score float64
+	}
+
+	scored := make([]scoredUnit, 0, len(units))
+	for _, u := range units {
+		scored = append(scored, scoredUnit{
+			snippetUnit: u,
+			Score:       scoreUnit(res, df, u, len(content), relLength),
+		})
+	}
+
+	sort.Slice(scored, func(i, j int) bool {
+		return scored[i].Score > scored[j].Score
+	})
+
+	var picked []scoredUnit
+	for _, u := range scored {
+		overlaps := false
+		for _, p := range picked {
+			if u.Start < p.End && p.Start < u.End {
+				overlaps = true
+				break
+			}
+		}
+
+		if overlaps {
+			continue
+		}
+
+		picked = append(picked, u)
+		if len(picked) >= snippetMaxUnits {
+			break
+		}
+	}
+
+	sort.Slice(picked, func(i, j int) bool {
+		return picked[i].Start < picked[j].Start
+	})
+
+	snippets := make([]Snippet, 0, len(picked))
+	for _, p := range picked {
+		snippets = append(snippets, Snippet{
+			Content:  indicator + content[p.Start:p.End] + indicator,
+			StartPos: p.Start,
+			EndPos:   p.End,
+		})
+	}
+
+	return snippets
+}
+
+// segmentUnits splits content into the non-overlapping units extractRelevantV4
+// scores and picks between, choosing a sentence based split for prose-like
+// extensions and a line-block based split for everything else.
+func segmentUnits(filename string, content string, relLength int) []snippetUnit {
+	if proseExtensions[strings.ToLower(filepath.Ext(filename))] {
+		return segmentSentences(content, relLength)
+	}
+
+	return segmentLineBlocks(content, relLength)
+}
+
+// segmentLineBlocks groups contiguous non-blank lines into units of up to
+// relLength bytes each; a blank line always ends the current unit, and a
+// single line longer than relLength forms a unit of its own.
+func segmentLineBlocks(content string, relLength int) []snippetUnit {
+	var units []snippetUnit
+
+	pos := 0
+	blockStart := -1
+
+	for pos <= len(content) {
+		nl := strings.IndexByte(content[pos:], '\n')
+
+		var line string
+		var nextPos int
+		if nl == -1 {
+			line = content[pos:]
+			nextPos = len(content) + 1
+		} else {
+			line = content[pos : pos+nl]
+			nextPos = pos + nl + 1
+		}
+
+		if strings.TrimSpace(line) == "" {
+			if blockStart != -1 {
+				units = append(units, snippetUnit{Start: blockStart, End: pos})
+				blockStart = -1
+			}
+		} else {
+			end := nextPos
+			if end > len(content) {
+				end = len(content)
+			}
+
+			// Close the current block before this line when adding the
+			// line would take it past relLength.
+			if blockStart != -1 && end-blockStart > relLength {
+				units = append(units, snippetUnit{Start: blockStart, End: pos})
+				blockStart = -1
+			}
+
+			if blockStart == -1 {
+				blockStart = pos
+			}
+
+			if end-blockStart >= relLength {
+				units = append(units, snippetUnit{Start: blockStart, End: end})
+				blockStart = -1
+			}
+		}
+
+		pos = nextPos
+	}
+
+	if blockStart != -1 && blockStart < len(content) {
+		units = append(units, snippetUnit{Start: blockStart, End: len(content)})
+	}
+
+	return units
+}
+
+// segmentSentences groups sentences, split on '.', '!' and '?', into
+// units of up to relLength bytes each. A single sentence (or trailing
+// unterminated text) longer than relLength forms a unit of its own.
+func segmentSentences(content string, relLength int) []snippetUnit {
+	var units []snippetUnit
+	blockStart := 0
+	lastEnd := 0
+
+	for i, r := range content {
+		if r == '.' || r == '!' || r == '?' {
+			end := i + 1
+			if end-blockStart > relLength && lastEnd > blockStart {
+				units = append(units, snippetUnit{Start: blockStart, End: lastEnd})
+				blockStart = lastEnd
+			}
+			if end-blockStart >= relLength {
+				units = append(units, snippetUnit{Start: blockStart, End: end})
+				blockStart = end
+			}
+			lastEnd = end
+		}
+	}
+
+	if len(content)-blockStart > relLength && lastEnd > blockStart {
+		units = append(units, snippetUnit{Start: blockStart, End: lastEnd})
+		blockStart = lastEnd
+	}
+
+	if blockStart < len(content) {
+		units = append(units, snippetUnit{Start: blockStart, End: len(content)})
+	}
+
+	return units
+}
+
+// scoreUnit implements
+//
+//	Σ_{term t in unit} (1 + log tf_unit,t) * idf_t
+//	  + snippetDistinctTermWeight * distinctTerms
+//	  + snippetEarlyPositionWeight * earlyPositionBonus
+//	  - snippetLengthPenaltyWeight * unitLengthPenalty
+//
+// idf_t is approximated as 1/df_t (df coming from df, the corpus-wide
+// document frequency): the rarer a term is across the whole result set,
+// the more a unit containing it is worth. earlyPositionBonus favours
+// units near the start of the file, on the basis that summaries, package
+// docs and the most relevant code usually live up top.
+func scoreUnit(res *fileJob, df map[string]int, u snippetUnit, totalLength int, relLength int) float64 {
+	termFreq := map[string]int{}
+
+	for term, locations := range res.MatchLocations {
+		for _, loc := range locations {
+			if len(loc) > 0 && loc[0] >= u.Start && loc[0] < u.End {
+				termFreq[term]++
+			}
+		}
+	}
+
+	var score float64
+	for term, tf := range termFreq {
+		idf := 0.0
+		if d := df[term]; d > 0 {
+			idf = 1 / float64(d)
+		}
+
+		score += (1 + math.Log(float64(tf))) * idf
+	}
+
+	earlyPositionBonus := 1.0
+	if totalLength > 0 {
+		earlyPositionBonus = 1 / (1 + float64(u.Start)/float64(totalLength))
+	}
+
+	unitLengthPenalty := 0.0
+	if relLength > 0 {
+		unitLengthPenalty = float64(u.End-u.Start) / float64(relLength)
+	}
+
+	score += snippetDistinctTermWeight * float64(len(termFreq))
+	score += snippetEarlyPositionWeight * earlyPositionBonus
+	score -= snippetLengthPenaltyWeight * unitLengthPenalty
+
+	return score
+}
+
 // Gets a substring of a string rune aware without allocating additional memory at the expense
 // of some additional CPU for a loop over the top which is probably worth it.
 // Literally copy/pasted from below link
@@ -0,0 +1,80 @@
+// SPDX-License-Identifier: MIT
+// SPDX-License-Identifier: Unlicense
+
+package processor
+
+import "testing"
+
+func TestExtractRelevantV4LandsOnLineBoundaries(t *testing.T) {
+	content := "package main\n\nfunc main() {\n\tfmt.Println(\"example\")\n}\n\nfunc other() {\n\treturn\n}\n"
+
+	res := &fileJob{
+		Filename: "main.go",
+		Content:  []byte(content),
+		MatchLocations: map[string][][]int{
+			"example": {{42, 49}},
+		},
+	}
+
+	df := map[string]int{"example": 1}
+
+	snippets := extractRelevantV4(res, df, 20, "…")
+	if len(snippets) == 0 {
+		t.Fatal("expected at least one snippet")
+	}
+
+	for _, s := range snippets {
+		if s.StartPos > 0 && content[s.StartPos-1] != '\n' {
+			t.Errorf("expected snippet to start on a line boundary, got %q", content[s.StartPos:])
+		}
+
+		if s.EndPos < len(content) && content[s.EndPos-1] != '\n' {
+			t.Errorf("expected snippet to end on a line boundary, got %q", content[:s.EndPos])
+		}
+	}
+}
+
+func TestExtractRelevantV4ShortContentReturnedWhole(t *testing.T) {
+	res := &fileJob{
+		Filename: "main.go",
+		Content:  []byte("short"),
+	}
+
+	snippets := extractRelevantV4(res, map[string]int{}, 100, "…")
+
+	if len(snippets) != 1 || snippets[0].Content != "short" {
+		t.Errorf("expected the whole short file back unchanged, got %+v", snippets)
+	}
+}
+
+func TestSegmentUnitsProseSplitsOnSentences(t *testing.T) {
+	content := "This is one sentence. This is another sentence! Is this a third?"
+
+	units := segmentUnits("README.md", content, 10)
+	if len(units) < 2 {
+		t.Fatalf("expected multiple sentence units, got %d", len(units))
+	}
+
+	for _, u := range units {
+		if u.End <= u.Start {
+			t.Errorf("unit has non-positive length: %+v", u)
+		}
+	}
+}
+
+func TestSelectSnippetsDispatchesByAlgo(t *testing.T) {
+	res := &fileJob{
+		Filename: "main.go",
+		Content:  []byte("some content with a needle in it that is long enough to need a snippet cut at all"),
+		MatchLocations: map[string][][]int{
+			"needle": {{20, 26}},
+		},
+	}
+
+	for _, algo := range []string{"v1", "v2", "v3", "v4"} {
+		snippets := selectSnippets(res, map[string]int{"needle": 1}, 20, "…", algo)
+		if len(snippets) == 0 {
+			t.Errorf("algo %s returned no snippets", algo)
+		}
+	}
+}
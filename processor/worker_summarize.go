@@ -20,6 +20,52 @@ type ResultSummarizer struct {
 	NoColor          bool
 	Format           string
 	FileOutput       string
+
+	// Algorithm selects the ranker combination used by rankResults.
+	// "v1" (the default) is TF-IDF plus the filename location boost.
+	// "v2" layers the fzf inspired path bonus ranker on top of v1 and
+	// requires Query to be set so it knows what was searched for.
+	// "bm25" swaps TF-IDF for Okapi BM25 while keeping the location boost.
+	Algorithm string
+	Query     string
+	// BonusWeight tunes how heavily rankResultsV2 weights its path
+	// match bonus relative to the rest of the score. NewResultSummarizer
+	// sets this to defaultBonusWeight; set it to 0 explicitly to turn
+	// the v2 path bonus contribution off entirely.
+	BonusWeight float64
+
+	// Explain, set via --explain, causes every result to carry an
+	// Explanation tree describing how its Score was arrived at, which
+	// is then printed (default format) or embedded (json format)
+	// alongside the result.
+	Explain bool
+
+	// SnippetAlgo selects which extractRelevantV{1,2,3,4} powers
+	// snippet extraction. "v4" (the default) is the unified line/sentence
+	// aware extractor; v1-v3 are kept reachable via --snippet-algo for
+	// compatibility with existing scripts/output expectations.
+	SnippetAlgo string
+
+	// RegexMode is set when the query came from --regex, i.e. Content
+	// was searched with a RegexSearcher rather than a flat term list.
+	// It switches formatDefault over to highlightMultiColor so that
+	// each expression gets its own highlight colour.
+	RegexMode bool
+
+	// Index, set via --index, turns on the trigram SearchSet built by
+	// search_set.go. Each result has its SearchSet loaded from (or, if
+	// missing, built and saved to) its on-disk sidecar, then checked
+	// against Query with IsCandidate before ranking and snippet
+	// extraction run over it, so that repeated queries against a
+	// stable corpus stop paying for work on files the index can
+	// already rule out.
+	Index bool
+	// MaxErrorRate is the IsCandidate tolerance used when Index is
+	// set. Zero (the default) requires the full expected trigram
+	// overlap; raising it trades false-positive candidates for
+	// tolerance of fuzzy/regex queries whose matched text does not
+	// literally share n-grams with Query.
+	MaxErrorRate float64
 }
 
 func NewResultSummarizer(input chan *fileJob) ResultSummarizer {
@@ -30,6 +76,9 @@ func NewResultSummarizer(input chan *fileJob) ResultSummarizer {
 		NoColor:      os.Getenv("TERM") == "dumb" || (!isatty.IsTerminal(os.Stdout.Fd()) && !isatty.IsCygwinTerminal(os.Stdout.Fd())),
 		Format:       Format,
 		FileOutput:   FileOutput,
+		Algorithm:    "v1",
+		BonusWeight:  defaultBonusWeight,
+		SnippetAlgo:  "v4",
 	}
 }
 
@@ -48,7 +97,17 @@ func (f *ResultSummarizer) Start() {
 		}
 	}
 
-	rankResults(int(f.FileReaderWorker.GetFileCount()), results)
+	if f.Explain {
+		for _, res := range results {
+			res.Explain = true
+		}
+	}
+
+	if f.Index {
+		results = f.filterByIndex(results)
+	}
+
+	rankResults(int(f.FileReaderWorker.GetFileCount()), results, f.Algorithm, f.Query, f.BonusWeight)
 
 	switch f.Format {
 	case "json":
@@ -58,13 +117,50 @@ func (f *ResultSummarizer) Start() {
 	}
 }
 
+// filterByIndex drops results whose SearchSet is not a candidate for
+// f.Query, loading each result's SearchSet from its on-disk sidecar
+// when --index has already built one for this corpus, or building and
+// persisting one otherwise so the next invocation amortizes the cost.
+func (f *ResultSummarizer) filterByIndex(results []*fileJob) []*fileJob {
+	queryHashes := QueryHashes(f.Query, 0)
+
+	candidates := make([]*fileJob, 0, len(results))
+	for _, res := range results {
+		if res.SearchSet == nil {
+			res.SearchSet = f.loadOrBuildSearchSet(res)
+		}
+
+		if res.SearchSet.IsCandidate(queryHashes, f.MaxErrorRate) {
+			candidates = append(candidates, res)
+		}
+	}
+
+	return candidates
+}
+
+// loadOrBuildSearchSet returns res's persisted SearchSet if its sidecar
+// already exists, otherwise builds one from res.Content and saves it so
+// later runs over the same corpus can skip rebuilding it.
+func (f *ResultSummarizer) loadOrBuildSearchSet(res *fileJob) *SearchSet {
+	sidecar := SearchSetSidecarPath(res.Location)
+
+	if set, err := LoadSearchSet(sidecar); err == nil {
+		return set
+	}
+
+	set := BuildSearchSet(res.Content, DefaultNGramSize)
+	_ = SaveSearchSet(sidecar, set)
+
+	return set
+}
+
 func (f *ResultSummarizer) formatJson(results []*fileJob) {
 	var jsonResults []jsonResult
 
 	documentFrequency := calculateDocumentTermFrequency(results)
 
 	for _, res := range results {
-		v3 := extractRelevantV3(res, documentFrequency, int(SnippetLength), "…")[0]
+		v3 := selectSnippets(res, documentFrequency, int(SnippetLength), "…", f.SnippetAlgo)[0]
 
 		// We have the snippet so now we need to highlight it
 		// we get all the locations that fall in the snippet length
@@ -87,6 +183,7 @@ func (f *ResultSummarizer) formatJson(results []*fileJob) {
 			Content:        v3.Content,
 			Score:          res.Score,
 			MatchLocations: l,
+			Explanation:    res.Explanation,
 		})
 	}
 
@@ -112,7 +209,11 @@ func (f *ResultSummarizer) formatDefault(results []*fileJob) {
 	for _, res := range results {
 		color.Magenta(fmt.Sprintf("%s (%.3f)", res.Location, res.Score))
 
-		snippets := extractRelevantV3(res, documentFrequency, int(SnippetLength), "…")
+		if f.Explain && res.Explanation != nil {
+			fmt.Print(res.Explanation.String())
+		}
+
+		snippets := selectSnippets(res, documentFrequency, int(SnippetLength), "…", f.SnippetAlgo)
 
 		if int64(len(snippets)) > f.SnippetCount {
 			snippets = snippets[:f.SnippetCount]
@@ -126,12 +227,21 @@ func (f *ResultSummarizer) formatDefault(results []*fileJob) {
 			// and then remove the length of the snippet cut which
 			// makes out location line up with the snippet size
 			var l [][]int
-			for _, value := range res.MatchLocations {
+			var multiColor [][3]int
+			for term, value := range res.MatchLocations {
+				expressionIdx, isRegexMatch := parseRegexMatchKey(term)
+
 				for _, s := range value {
 					if s[0] >= snippets[i].StartPos && s[1] <= snippets[i].EndPos {
-						s[0] = s[0] - snippets[i].StartPos
-						s[1] = s[1] - snippets[i].StartPos
+						start := s[0] - snippets[i].StartPos
+						end := s[1] - snippets[i].StartPos
+						s[0] = start
+						s[1] = end
 						l = append(l, s)
+
+						if isRegexMatch {
+							multiColor = append(multiColor, [3]int{start, end, expressionIdx})
+						}
 					}
 				}
 			}
@@ -141,7 +251,11 @@ func (f *ResultSummarizer) formatDefault(results []*fileJob) {
 			// If the start and end pos are 0 then we don't need to highlight because there is
 			// nothing to do so, which means its likely to be a filename match with no content
 			if !(snippets[i].StartPos == 0 && snippets[i].EndPos == 0) {
-				displayContent = str.HighlightString(snippets[i].Content, l, fmtBegin, fmtEnd)
+				if f.RegexMode {
+					displayContent = highlightMultiColor(snippets[i].Content, multiColor, fmtEnd)
+				} else {
+					displayContent = str.HighlightString(snippets[i].Content, l, fmtBegin, fmtEnd)
+				}
 			}
 
 			fmt.Println(displayContent)
@@ -157,9 +271,10 @@ func (f *ResultSummarizer) formatDefault(results []*fileJob) {
 }
 
 type jsonResult struct {
-	Filename       string  `json:"filename"`
-	Location       string  `json:"location"`
-	Content        string  `json:"content"`
-	Score          float64 `json:"score"`
-	MatchLocations [][]int `json:"matchlocations"`
+	Filename       string       `json:"filename"`
+	Location       string       `json:"location"`
+	Content        string       `json:"content"`
+	Score          float64      `json:"score"`
+	MatchLocations [][]int      `json:"matchlocations"`
+	Explanation    *Explanation `json:"explanation,omitempty"`
 }
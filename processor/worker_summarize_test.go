@@ -0,0 +1,54 @@
+// SPDX-License-Identifier: MIT
+// SPDX-License-Identifier: Unlicense
+
+package processor
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestFilterByIndexDropsNonCandidates(t *testing.T) {
+	f := &ResultSummarizer{
+		Index: true,
+		Query: "the quick brown fox",
+	}
+
+	match := &fileJob{
+		Location: filepath.Join(t.TempDir(), "match.go"),
+		Content:  []byte("the quick brown fox jumps over the lazy dog"),
+	}
+	noMatch := &fileJob{
+		Location: filepath.Join(t.TempDir(), "nomatch.go"),
+		Content:  []byte("completely unrelated file content"),
+	}
+
+	results := f.filterByIndex([]*fileJob{match, noMatch})
+
+	if len(results) != 1 || results[0] != match {
+		t.Fatalf("expected only the matching file to survive filtering, got %+v", results)
+	}
+}
+
+func TestLoadOrBuildSearchSetPersistsSidecar(t *testing.T) {
+	f := &ResultSummarizer{}
+
+	res := &fileJob{
+		Location: filepath.Join(t.TempDir(), "content.go"),
+		Content:  []byte("some content to index"),
+	}
+
+	built := f.loadOrBuildSearchSet(res)
+	if built == nil || len(built.Hashes) == 0 {
+		t.Fatal("expected a non-empty SearchSet to be built")
+	}
+
+	loaded, err := LoadSearchSet(SearchSetSidecarPath(res.Location))
+	if err != nil {
+		t.Fatalf("expected the SearchSet to have been persisted: %v", err)
+	}
+
+	if len(loaded.Hashes) != len(built.Hashes) {
+		t.Errorf("expected sidecar to round-trip %d hashes, got %d", len(built.Hashes), len(loaded.Hashes))
+	}
+}